@@ -1,28 +1,264 @@
 package customdeepequal
 
 import (
+	"fmt"
+	"math"
 	"reflect"
+	"strconv"
+	"strings"
+	"sync"
 	"unsafe"
 )
 
+// structTag is the struct tag key honored by deepValueEqual's reflect.Struct
+// case, e.g. `deepequal:"ignore"`, `deepequal:"set"`, `deepequal:"epsilon=0.0001"`
+// or `deepequal:"eq=FuncName"`.
+const structTag = "deepequal"
+
+// fieldOptions holds the parsed deepequal struct tag options for a single field.
+type fieldOptions struct {
+	ignore  bool
+	set     bool
+	epsilon float64
+	hasEps  bool
+	eqName  string
+}
+
+// fieldOptionsCache caches parsed deepequal struct tags per reflect.Type so
+// the tag string only has to be parsed once per struct type, not once per
+// comparison.
+var fieldOptionsCache sync.Map // map[reflect.Type][]fieldOptions
+
+func fieldOptionsFor(t reflect.Type) []fieldOptions {
+	if cached, ok := fieldOptionsCache.Load(t); ok {
+		return cached.([]fieldOptions)
+	}
+	opts := make([]fieldOptions, t.NumField())
+	for i := range opts {
+		opts[i] = parseFieldTag(t.Field(i).Tag.Get(structTag))
+	}
+	fieldOptionsCache.Store(t, opts)
+	return opts
+}
+
+func parseFieldTag(tag string) fieldOptions {
+	var opts fieldOptions
+	if tag == "" {
+		return opts
+	}
+	for _, part := range strings.Split(tag, ",") {
+		switch {
+		case part == "ignore":
+			opts.ignore = true
+		case part == "set":
+			opts.set = true
+		case strings.HasPrefix(part, "epsilon="):
+			if v, err := strconv.ParseFloat(strings.TrimPrefix(part, "epsilon="), 64); err == nil {
+				opts.hasEps = true
+				opts.epsilon = v
+			}
+		case strings.HasPrefix(part, "eq="):
+			opts.eqName = strings.TrimPrefix(part, "eq=")
+		}
+	}
+	return opts
+}
+
 // CustomDeepEquals allows registering custom equality functions for recursively
 // traversed fields based on the type.
 // For pointer types it deferences the pointer and then runs the comparison
 // For comparison to work the input structs must be of the exact same type
+//
+// The registry is safe for concurrent use: registration takes mu for writing,
+// and deepValueEqual takes it for reading, so RegisterX calls may race with
+// DeepEqual calls from other goroutines.
 type CustomDeepEquals struct {
 	CustomEqualityCheckers map[reflect.Type]func(a, b unsafe.Pointer) bool
+	equalityFuncs          map[reflect.Type]reflect.Value
+	namedComparators       map[string]reflect.Value
+	unorderedSliceTypes    map[reflect.Type]bool
+
+	mu sync.RWMutex
 }
 
 // NewCustomDeepEquals creates an new CustomDeepEquals
-func NewCustomDeepEquals() CustomDeepEquals {
-	return CustomDeepEquals{make(map[reflect.Type]func(a unsafe.Pointer, b unsafe.Pointer) bool)}
+func NewCustomDeepEquals() *CustomDeepEquals {
+	return &CustomDeepEquals{
+		CustomEqualityCheckers: make(map[reflect.Type]func(a unsafe.Pointer, b unsafe.Pointer) bool),
+		equalityFuncs:          make(map[reflect.Type]reflect.Value),
+		namedComparators:       make(map[string]reflect.Value),
+		unorderedSliceTypes:    make(map[reflect.Type]bool),
+	}
+}
+
+// Clone returns an independent copy of c's registry. Registering a type or
+// function on the clone does not affect c, and vice versa, which is useful
+// for giving each test its own isolated set of overrides starting from a
+// shared baseline.
+func (c *CustomDeepEquals) Clone() *CustomDeepEquals {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	clone := &CustomDeepEquals{
+		CustomEqualityCheckers: make(map[reflect.Type]func(a unsafe.Pointer, b unsafe.Pointer) bool, len(c.CustomEqualityCheckers)),
+		equalityFuncs:          make(map[reflect.Type]reflect.Value, len(c.equalityFuncs)),
+		namedComparators:       make(map[string]reflect.Value, len(c.namedComparators)),
+		unorderedSliceTypes:    make(map[reflect.Type]bool, len(c.unorderedSliceTypes)),
+	}
+	for k, v := range c.CustomEqualityCheckers {
+		clone.CustomEqualityCheckers[k] = v
+	}
+	for k, v := range c.equalityFuncs {
+		clone.equalityFuncs[k] = v
+	}
+	for k, v := range c.namedComparators {
+		clone.namedComparators[k] = v
+	}
+	for k, v := range c.unorderedSliceTypes {
+		clone.unorderedSliceTypes[k] = v
+	}
+	return clone
 }
 
 // RegisterEquivalenceForType registers the equals function for the given type
 func (c *CustomDeepEquals) RegisterEquivalenceForType(ty reflect.Type, equals func(a, b unsafe.Pointer) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	c.CustomEqualityCheckers[ty] = equals
 }
 
+// RegisterEqualityFunc registers a type-safe equality function of the form
+// func(T, T) bool for some concrete type T. Unlike RegisterEquivalenceForType
+// it takes an ordinary Go function instead of requiring the caller to write
+// unsafe.Pointer casts, and deepValueEqual invokes it via reflect.Value.Call.
+func (c *CustomDeepEquals) RegisterEqualityFunc(eqFunc interface{}) error {
+	fnVal := reflect.ValueOf(eqFunc)
+	fnType := fnVal.Type()
+	if fnType.Kind() != reflect.Func {
+		return fmt.Errorf("customdeepequal: RegisterEqualityFunc requires a func, got %s", fnType.Kind())
+	}
+	if fnType.NumIn() != 2 || fnType.In(0) != fnType.In(1) {
+		return fmt.Errorf("customdeepequal: equality func must have signature func(T, T) bool, got %s", fnType)
+	}
+	if fnType.NumOut() != 1 || fnType.Out(0).Kind() != reflect.Bool {
+		return fmt.Errorf("customdeepequal: equality func must return bool, got %s", fnType)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.equalityFuncs == nil {
+		c.equalityFuncs = make(map[reflect.Type]reflect.Value)
+	}
+	c.equalityFuncs[fnType.In(0)] = fnVal
+	return nil
+}
+
+// AddFuncs registers multiple equality functions at once, in the style of
+// func(T, T) bool expected by RegisterEqualityFunc. It stops and returns the
+// first validation error encountered.
+func (c *CustomDeepEquals) AddFuncs(eqFuncs ...interface{}) error {
+	for _, eqFunc := range eqFuncs {
+		if err := c.RegisterEqualityFunc(eqFunc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddFuncsOrDie is like AddFuncs but panics if any function fails to register.
+func (c *CustomDeepEquals) AddFuncsOrDie(eqFuncs ...interface{}) {
+	if err := c.AddFuncs(eqFuncs...); err != nil {
+		panic(err)
+	}
+}
+
+// RegisterNamedComparator registers a type-safe equality function of the form
+// func(T, T) bool under a name, for use from a `deepequal:"eq=Name"` struct
+// tag on a field of type T. This lets a single field of one struct opt into
+// custom comparison without registering an equality function for the whole
+// enclosing type via RegisterEqualityFunc.
+func (c *CustomDeepEquals) RegisterNamedComparator(name string, fn interface{}) error {
+	fnVal := reflect.ValueOf(fn)
+	fnType := fnVal.Type()
+	if fnType.Kind() != reflect.Func {
+		return fmt.Errorf("customdeepequal: RegisterNamedComparator requires a func, got %s", fnType.Kind())
+	}
+	if fnType.NumIn() != 2 || fnType.In(0) != fnType.In(1) {
+		return fmt.Errorf("customdeepequal: comparator func must have signature func(T, T) bool, got %s", fnType)
+	}
+	if fnType.NumOut() != 1 || fnType.Out(0).Kind() != reflect.Bool {
+		return fmt.Errorf("customdeepequal: comparator func must return bool, got %s", fnType)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.namedComparators == nil {
+		c.namedComparators = make(map[string]reflect.Value)
+	}
+	c.namedComparators[name] = fnVal
+	return nil
+}
+
+// RegisterUnorderedSlice opts slices of elemType into unordered (permutation)
+// comparison everywhere they're encountered, as a global alternative to the
+// per-field `deepequal:"set"` struct tag. Ordered comparison remains the
+// default for every other slice type, since unordered matching is O(n^2) in
+// the slice length.
+func (c *CustomDeepEquals) RegisterUnorderedSlice(elemType reflect.Type) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.unorderedSliceTypes == nil {
+		c.unorderedSliceTypes = make(map[reflect.Type]bool)
+	}
+	c.unorderedSliceTypes[elemType] = true
+}
+
+// callEqualityFunc invokes a registered typed equality function on v1 and v2.
+// reflect.Value.Call panics on values obtained through unexported struct
+// fields, so when either value can't be interfaced we rewrap it as an
+// addressable, settable value via reflect.NewAt before calling.
+func callEqualityFunc(fn reflect.Value, v1, v2 reflect.Value) bool {
+	if !v1.CanInterface() {
+		v1 = reflect.NewAt(v1.Type(), unsafe.Pointer(v1.UnsafeAddr())).Elem()
+	}
+	if !v2.CanInterface() {
+		v2 = reflect.NewAt(v2.Type(), unsafe.Pointer(v2.UnsafeAddr())).Elem()
+	}
+	return fn.Call([]reflect.Value{v1, v2})[0].Bool()
+}
+
+// canCallEqualityFunc reports whether v can safely be passed to
+// callEqualityFunc. v must either be directly interfaceable, or addressable
+// so callEqualityFunc can rewrap it via reflect.NewAt(...UnsafeAddr()). A
+// value that is neither - e.g. read from an unexported field of a
+// non-addressable parent passed by value - has no valid rewrap and would
+// panic on UnsafeAddr.
+func canCallEqualityFunc(v1, v2 reflect.Value) bool {
+	return (v1.CanInterface() || v1.CanAddr()) && (v2.CanInterface() || v2.CanAddr())
+}
+
+// scalarEqual compares two values of one of the basic kinds that fall
+// through to deepValueEqual's default case, using their Kind-specific
+// accessors (Bool, Int, Uint, Float, Complex, Pointer) rather than reading
+// raw bytes through UnsafeAddr. Unlike UnsafeAddr these accessors work on
+// values that are read-only and non-addressable, which is what lets this
+// serve as the fallback for values with no valid UnsafeAddr.
+func scalarEqual(v1, v2 reflect.Value) bool {
+	switch v1.Kind() {
+	case reflect.Bool:
+		return v1.Bool() == v2.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v1.Int() == v2.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v1.Uint() == v2.Uint()
+	case reflect.Float32, reflect.Float64:
+		return v1.Float() == v2.Float()
+	case reflect.Complex64, reflect.Complex128:
+		return v1.Complex() == v2.Complex()
+	case reflect.Chan, reflect.UnsafePointer:
+		return v1.Pointer() == v2.Pointer()
+	default:
+		return v1.CanInterface() && v2.CanInterface() && v1.Interface() == v2.Interface()
+	}
+}
+
 // During deepValueEqual, must keep track of checks that are
 // in progress. The comparison algorithm assumes that all
 // checks in progress are true when it reencounters them.
@@ -33,27 +269,184 @@ type visit struct {
 	typ reflect.Type
 }
 
+// Difference describes a single point at which two values passed to
+// DeepEqualDiff were found not to be equal.
+type Difference struct {
+	Path   string
+	A, B   interface{}
+	Reason string
+}
+
+// interfaceOf returns v's underlying value for embedding in a Difference,
+// falling back to the same unexported-field rewrap used by callEqualityFunc
+// since Difference.A/B are populated even for values read from unexported
+// fields. When v is neither interfaceable nor addressable - e.g. read from
+// an unexported field of a non-addressable parent - there is no valid
+// rewrap, and interfaceOf returns nil; the Difference is still recorded, but
+// its A/B payload is empty in that case.
+func interfaceOf(v reflect.Value) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+	if v.CanInterface() {
+		return v.Interface()
+	}
+	if v.CanAddr() {
+		return reflect.NewAt(v.Type(), unsafe.Pointer(v.UnsafeAddr())).Elem().Interface()
+	}
+	return nil
+}
+
+// mapKeyString formats a map key for use in a Difference.Path. Map keys are
+// never addressable, so a key read from an unexported struct field's map
+// can't be rewrapped like interfaceOf does for addressable values; fall back
+// to Kind-specific accessors instead of calling Interface() on a read-only
+// Value, which would panic.
+func mapKeyString(k reflect.Value) string {
+	if k.CanInterface() {
+		return fmt.Sprintf("%v", k.Interface())
+	}
+	switch k.Kind() {
+	case reflect.String:
+		return k.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return fmt.Sprintf("%d", k.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return fmt.Sprintf("%d", k.Uint())
+	case reflect.Float32, reflect.Float64:
+		return fmt.Sprintf("%v", k.Float())
+	case reflect.Bool:
+		return fmt.Sprintf("%v", k.Bool())
+	default:
+		return fmt.Sprintf("<%s>", k.Type())
+	}
+}
+
+// compareWithNamedComparator applies a comparator registered via
+// RegisterNamedComparator to a single field. If the name isn't registered it
+// falls back to the regular recursive comparison so a typo'd tag degrades
+// gracefully instead of silently treating the field as always-equal.
+func (c *CustomDeepEquals) compareWithNamedComparator(name string, v1, v2 reflect.Value, visited map[visit]bool, depth int, path string, diffs *[]Difference) bool {
+	c.mu.RLock()
+	fn, ok := c.namedComparators[name]
+	c.mu.RUnlock()
+	if !ok {
+		return c.deepValueEqual(v1, v2, visited, depth, path, diffs)
+	}
+	if callEqualityFunc(fn, v1, v2) {
+		return true
+	}
+	if diffs != nil {
+		*diffs = append(*diffs, Difference{Path: path, A: interfaceOf(v1), B: interfaceOf(v2), Reason: "custom comparator rejected"})
+	}
+	return false
+}
+
+// compareWithEpsilon implements the `epsilon=` tag option for float fields,
+// treating v1 and v2 as equal if they're within epsilon of each other.
+func compareWithEpsilon(v1, v2 reflect.Value, epsilon float64, path string, diffs *[]Difference) bool {
+	if math.Abs(v1.Float()-v2.Float()) <= epsilon {
+		return true
+	}
+	if diffs != nil {
+		*diffs = append(*diffs, Difference{Path: path, A: interfaceOf(v1), B: interfaceOf(v2), Reason: "epsilon mismatch"})
+	}
+	return false
+}
+
+// unorderedSliceEqual compares v1 and v2 as slices without regard to order,
+// matching each element of v1 to an unused element of v2. Element equality
+// can be user-defined and therefore not hashable, so matching is O(n^2): for
+// each element of v1, scan the unused elements of v2 for a partner using a
+// fresh visited map scoped to that element pair. Reached via a field tagged
+// `deepequal:"set"` or a slice element type registered with
+// RegisterUnorderedSlice; maps need no equivalent since MapKeys() already
+// makes map comparison order-independent.
+func (c *CustomDeepEquals) unorderedSliceEqual(v1, v2 reflect.Value, path string, diffs *[]Difference) bool {
+	if v1.IsNil() != v2.IsNil() {
+		if diffs != nil {
+			*diffs = append(*diffs, Difference{Path: path, A: interfaceOf(v1), B: interfaceOf(v2), Reason: "nil mismatch"})
+		}
+		return false
+	}
+	if v1.Len() != v2.Len() {
+		if diffs != nil {
+			*diffs = append(*diffs, Difference{Path: path, A: interfaceOf(v1), B: interfaceOf(v2), Reason: "length mismatch"})
+		}
+		return false
+	}
+	inUse := make([]bool, v2.Len())
+	for i := 0; i < v1.Len(); i++ {
+		found := false
+		for j := 0; j < v2.Len(); j++ {
+			if inUse[j] {
+				continue
+			}
+			if c.deepValueEqual(v1.Index(i), v2.Index(j), make(map[visit]bool), 0, "", nil) {
+				inUse[j] = true
+				found = true
+				break
+			}
+		}
+		if !found {
+			if diffs != nil {
+				*diffs = append(*diffs, Difference{Path: fmt.Sprintf("%s[%d]", path, i), A: interfaceOf(v1.Index(i)), Reason: "no matching element found"})
+			}
+			return false
+		}
+	}
+	return true
+}
+
 // Tests for deep equality using reflected types or custom type equivalence overrides.
-// The map argument tracks comparisons that have already been seen, which allows short
-// circuiting on recursive types.
-func (c *CustomDeepEquals) deepValueEqual(v1, v2 reflect.Value, visited map[visit]bool, depth int) bool {
+// The visited map tracks comparisons that have already been seen, which allows short
+// circuiting on recursive types. path records how we got here for use in Difference.Path.
+// diffs, if non-nil, collects every divergence found instead of stopping at the first one;
+// when it is nil the function returns as soon as a single inequality is found.
+func (c *CustomDeepEquals) deepValueEqual(v1, v2 reflect.Value, visited map[visit]bool, depth int, path string, diffs *[]Difference) bool {
+	record := func(reason string) {
+		if diffs != nil {
+			*diffs = append(*diffs, Difference{Path: path, A: interfaceOf(v1), B: interfaceOf(v2), Reason: reason})
+		}
+	}
+
 	if !v1.IsValid() || !v2.IsValid() {
-		return v1.IsValid() == v2.IsValid()
+		if v1.IsValid() == v2.IsValid() {
+			return true
+		}
+		record("validity mismatch")
+		return false
 	}
 	if v1.Type() != v2.Type() {
+		record("type mismatch")
+		return false
+	}
+
+	c.mu.RLock()
+	fn, hasFn := c.equalityFuncs[v1.Type()]
+	customEq, hasCustomEq := c.CustomEqualityCheckers[v1.Type()]
+	c.mu.RUnlock()
+
+	// hasFn and hasCustomEq are guarded the same way: if the value has no
+	// valid UnsafeAddr to read through (and, for hasFn, isn't directly
+	// interfaceable either), skip the registered override and fall through
+	// to the normal recursive comparison below instead of panicking.
+	if hasFn && canCallEqualityFunc(v1, v2) {
+		if callEqualityFunc(fn, v1, v2) {
+			return true
+		}
+		record("custom equality rejected")
 		return false
 	}
 
-	customEq, ok := c.CustomEqualityCheckers[v1.Type()]
-	if ok {
+	if hasCustomEq && v1.CanAddr() && v2.CanAddr() {
 		v1Val := unsafe.Pointer(v1.UnsafeAddr())
 		v2Val := unsafe.Pointer(v2.UnsafeAddr())
-		return customEq(v1Val, v2Val)
-		//Can't do the it the right way below since this value might
-		//be an unexported field, and go has no way to generically do
-		//a cast
-		//fn := reflect.ValueOf(customEq)
-		//return fn.Call([]reflect.Value{v1, v2})[0].Bool()
+		if customEq(v1Val, v2Val) {
+			return true
+		}
+		record("custom equality rejected")
+		return false
 	}
 	// if depth > 10 { panic("deepValueEqual") }	// for debugging
 
@@ -90,72 +483,153 @@ func (c *CustomDeepEquals) deepValueEqual(v1, v2 reflect.Value, visited map[visi
 
 	switch v1.Kind() {
 	case reflect.Array:
+		equal := true
 		for i := 0; i < v1.Len(); i++ {
-			if !c.deepValueEqual(v1.Index(i), v2.Index(i), visited, depth+1) {
-				return false
+			if !c.deepValueEqual(v1.Index(i), v2.Index(i), visited, depth+1, fmt.Sprintf("%s[%d]", path, i), diffs) {
+				if diffs == nil {
+					return false
+				}
+				equal = false
 			}
 		}
-		return true
+		return equal
 	case reflect.Slice:
+		c.mu.RLock()
+		unordered := c.unorderedSliceTypes[v1.Type().Elem()]
+		c.mu.RUnlock()
+		if unordered {
+			return c.unorderedSliceEqual(v1, v2, path, diffs)
+		}
 		if v1.IsNil() != v2.IsNil() {
+			record("nil mismatch")
 			return false
 		}
 		if v1.Len() != v2.Len() {
+			record("length mismatch")
 			return false
 		}
 		if v1.Pointer() == v2.Pointer() {
 			return true
 		}
+		equal := true
 		for i := 0; i < v1.Len(); i++ {
-			if !c.deepValueEqual(v1.Index(i), v2.Index(i), visited, depth+1) {
-				return false
+			if !c.deepValueEqual(v1.Index(i), v2.Index(i), visited, depth+1, fmt.Sprintf("%s[%d]", path, i), diffs) {
+				if diffs == nil {
+					return false
+				}
+				equal = false
 			}
 		}
-		return true
+		return equal
 	case reflect.Interface:
 		if v1.IsNil() || v2.IsNil() {
-			return v1.IsNil() == v2.IsNil()
+			if v1.IsNil() == v2.IsNil() {
+				return true
+			}
+			record("nil mismatch")
+			return false
 		}
-		return c.deepValueEqual(v1.Elem(), v2.Elem(), visited, depth+1)
+		return c.deepValueEqual(v1.Elem(), v2.Elem(), visited, depth+1, path, diffs)
 	case reflect.Ptr:
 		if v1.Pointer() == v2.Pointer() {
 			return true
 		}
-		return c.deepValueEqual(reflect.Indirect(v1), reflect.Indirect(v2), visited, depth+1)
+		return c.deepValueEqual(reflect.Indirect(v1), reflect.Indirect(v2), visited, depth+1, path+"(*)", diffs)
 	case reflect.Struct:
+		equal := true
+		opts := fieldOptionsFor(v1.Type())
 		for i, n := 0, v1.NumField(); i < n; i++ {
-			if !c.deepValueEqual(v1.Field(i), v2.Field(i), visited, depth+1) {
-				return false
+			fo := opts[i]
+			if fo.ignore {
+				continue
+			}
+			fieldPath := path + "." + v1.Type().Field(i).Name
+			f1, f2 := v1.Field(i), v2.Field(i)
+			var fieldEqual bool
+			switch {
+			case fo.eqName != "":
+				fieldEqual = c.compareWithNamedComparator(fo.eqName, f1, f2, visited, depth+1, fieldPath, diffs)
+			case fo.hasEps && (f1.Kind() == reflect.Float32 || f1.Kind() == reflect.Float64):
+				fieldEqual = compareWithEpsilon(f1, f2, fo.epsilon, fieldPath, diffs)
+			case fo.set && f1.Kind() == reflect.Slice:
+				fieldEqual = c.unorderedSliceEqual(f1, f2, fieldPath, diffs)
+			default:
+				fieldEqual = c.deepValueEqual(f1, f2, visited, depth+1, fieldPath, diffs)
+			}
+			if !fieldEqual {
+				if diffs == nil {
+					return false
+				}
+				equal = false
 			}
 		}
-		return true
+		return equal
 	case reflect.Map:
 		if v1.IsNil() != v2.IsNil() {
+			record("nil mismatch")
 			return false
 		}
 		if v1.Len() != v2.Len() {
+			record("length mismatch")
 			return false
 		}
 		if v1.Pointer() == v2.Pointer() {
 			return true
 		}
+		equal := true
 		for _, k := range v1.MapKeys() {
 			val1 := v1.MapIndex(k)
 			val2 := v2.MapIndex(k)
-			if !val1.IsValid() || !val2.IsValid() || !c.deepValueEqual(v1.MapIndex(k), v2.MapIndex(k), visited, depth+1) {
-				return false
+			// Only format the key into a path when it might actually be used:
+			// building it unconditionally would be wasted work on the
+			// diffs == nil fast path.
+			keyPath := path
+			if diffs != nil {
+				keyPath = fmt.Sprintf("%s[%s]", path, mapKeyString(k))
+			}
+			if !val1.IsValid() || !val2.IsValid() {
+				if diffs != nil {
+					*diffs = append(*diffs, Difference{Path: keyPath, A: interfaceOf(val1), B: interfaceOf(val2), Reason: "key missing"})
+				}
+				if diffs == nil {
+					return false
+				}
+				equal = false
+				continue
+			}
+			if !c.deepValueEqual(val1, val2, visited, depth+1, keyPath, diffs) {
+				if diffs == nil {
+					return false
+				}
+				equal = false
 			}
 		}
-		return true
+		return equal
 	case reflect.Func:
 		if v1.IsNil() && v2.IsNil() {
 			return true
 		}
 		// Can't do better than this:
+		record("functions cannot be compared")
 		return false
 	case reflect.String:
-		return v1.String() == v2.String()
+		if v1.String() == v2.String() {
+			return true
+		}
+		record("string mismatch")
+		return false
 	default:
+		// Values read from an unexported field of a non-addressable parent
+		// (e.g. a struct passed to DeepEqual by value) have no UnsafeAddr to
+		// read through, but their Kind-specific accessors work regardless of
+		// the read-only flag, so fall back to those instead of panicking.
+		if !v1.CanAddr() || !v2.CanAddr() {
+			if scalarEqual(v1, v2) {
+				return true
+			}
+			record("value mismatch")
+			return false
+		}
 		// Normal equality suffices
 		vb1 := (uintptr)(unsafe.Pointer(v1.UnsafeAddr()))
 		vb2 := (uintptr)(unsafe.Pointer(v2.UnsafeAddr()))
@@ -166,6 +640,7 @@ func (c *CustomDeepEquals) deepValueEqual(v1, v2 reflect.Value, visited map[visi
 			b1 := *(*byte)(unsafe.Pointer(v1Addr))
 			b2 := *(*byte)(unsafe.Pointer(v2Addr))
 			if b1 != b2 {
+				record("value mismatch")
 				return false
 			}
 		}
@@ -184,5 +659,75 @@ func (c *CustomDeepEquals) DeepEqual(x, y interface{}) bool {
 	if v1.Type() != v2.Type() {
 		return false
 	}
-	return c.deepValueEqual(v1, v2, make(map[visit]bool), 0)
+	// Passing a nil *[]Difference tells deepValueEqual to return as soon as it
+	// finds a single inequality instead of building a full diff list.
+	return c.deepValueEqual(v1, v2, make(map[visit]bool), 0, "", nil)
+}
+
+// DeepEqualDiff is like DeepEqual but instead of a single boolean it returns
+// every point at which x and y diverge, so callers can log or assert on
+// exactly what differed.
+func (c *CustomDeepEquals) DeepEqualDiff(x, y interface{}) []Difference {
+	var diffs []Difference
+	if x == nil || y == nil {
+		if x != y {
+			diffs = append(diffs, Difference{A: x, B: y, Reason: "nil mismatch"})
+		}
+		return diffs
+	}
+	v1 := reflect.ValueOf(x)
+	v2 := reflect.ValueOf(y)
+	if v1.Type() != v2.Type() {
+		diffs = append(diffs, Difference{A: x, B: y, Reason: "type mismatch"})
+		return diffs
+	}
+	c.deepValueEqual(v1, v2, make(map[visit]bool), 0, "", &diffs)
+	return diffs
+}
+
+// Default is the package-level CustomDeepEquals used by the top-level
+// DeepEqual, DeepEqualDiff and registration functions, giving this package
+// the same drop-in ergonomics as reflect.DeepEqual.
+var Default = NewCustomDeepEquals()
+
+// DeepEqual is a drop-in replacement for reflect.DeepEqual that honors
+// whatever has been registered on Default.
+func DeepEqual(x, y interface{}) bool {
+	return Default.DeepEqual(x, y)
+}
+
+// DeepEqualDiff reports every point at which x and y diverge, honoring
+// whatever has been registered on Default.
+func DeepEqualDiff(x, y interface{}) []Difference {
+	return Default.DeepEqualDiff(x, y)
+}
+
+// RegisterEquivalenceForType registers the equals function for the given type on Default.
+func RegisterEquivalenceForType(ty reflect.Type, equals func(a, b unsafe.Pointer) bool) {
+	Default.RegisterEquivalenceForType(ty, equals)
+}
+
+// RegisterEqualityFunc registers a type-safe equality function on Default.
+func RegisterEqualityFunc(eqFunc interface{}) error {
+	return Default.RegisterEqualityFunc(eqFunc)
+}
+
+// AddFuncs registers multiple type-safe equality functions on Default.
+func AddFuncs(eqFuncs ...interface{}) error {
+	return Default.AddFuncs(eqFuncs...)
+}
+
+// AddFuncsOrDie is like AddFuncs but panics if any function fails to register.
+func AddFuncsOrDie(eqFuncs ...interface{}) {
+	Default.AddFuncsOrDie(eqFuncs...)
+}
+
+// RegisterNamedComparator registers a named comparator on Default for use from a `deepequal:"eq=Name"` tag.
+func RegisterNamedComparator(name string, fn interface{}) error {
+	return Default.RegisterNamedComparator(name, fn)
+}
+
+// RegisterUnorderedSlice opts slices of elemType into unordered comparison on Default.
+func RegisterUnorderedSlice(elemType reflect.Type) {
+	Default.RegisterUnorderedSlice(elemType)
 }
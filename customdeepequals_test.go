@@ -21,7 +21,7 @@ type dtype struct {
 }
 
 func TestAll(t *testing.T) {
-	customDeep := CustomDeepEquals{make(map[reflect.Type]func(a unsafe.Pointer, b unsafe.Pointer) bool)}
+	customDeep := NewCustomDeepEquals()
 	now := time.Now()
 	customDeep.RegisterEquivalenceForType(reflect.TypeOf(now), func(a, b unsafe.Pointer) bool {
 		// Ugly code everyone will be forced to write
@@ -40,3 +40,216 @@ func TestAll(t *testing.T) {
 	}
 	t.Logf("The structs were deep equal")
 }
+
+func TestRegisterEqualityFunc(t *testing.T) {
+	customDeep := NewCustomDeepEquals()
+	if err := customDeep.RegisterEqualityFunc(func(a, b time.Time) bool {
+		return a.Unix() == b.Unix()
+	}); err != nil {
+		t.Fatalf("failed to register equality func: %v", err)
+	}
+
+	two := 2
+	two2 := 2
+	t1 := time.Date(2017, 4, 1, 0, 0, 0, 24534, time.UTC)
+	t2 := time.Date(2017, 4, 1, 0, 0, 0, 33454, time.UTC)
+	if !customDeep.DeepEqual(&dtype{d: &two, t: &t1}, &dtype{d: &two2, t: &t2}) {
+		t.Fatalf("The structs were not deep equal")
+	}
+
+	if err := customDeep.RegisterEqualityFunc(func(a, b int) string { return "" }); err == nil {
+		t.Fatalf("expected error when registering a func whose out param is not bool")
+	}
+	if err := customDeep.RegisterEqualityFunc(func(a int, b string) bool { return true }); err == nil {
+		t.Fatalf("expected error when registering a func whose params differ")
+	}
+	if err := customDeep.RegisterEqualityFunc("not a func"); err == nil {
+		t.Fatalf("expected error when registering a non-func")
+	}
+
+	// A registered-type value read from an unexported field of a struct
+	// passed by value (rather than by pointer) is neither interfaceable nor
+	// addressable - there's no UnsafeAddr to rewrap through, so this must
+	// fall back to the normal recursive comparison instead of panicking.
+	w1 := wrapper{t: t1}
+	w2 := wrapper{t: t1}
+	if !customDeep.DeepEqual(w1, w2) {
+		t.Fatalf("expected equal by-value wrappers to be deep equal")
+	}
+	w3 := wrapper{t: t1.Add(time.Second)}
+	if customDeep.DeepEqual(w1, w3) {
+		t.Fatalf("expected differing by-value wrappers to not be deep equal")
+	}
+}
+
+type wrapper struct {
+	t time.Time
+}
+
+// TestUnaddressableRegistrySymmetry checks that the legacy
+// CustomEqualityCheckers path and the newer equalityFuncs path behave the
+// same way when handed a registered-type value that's neither interfaceable
+// nor addressable: both must skip the registered override and fall back to
+// the normal recursive comparison rather than panicking on UnsafeAddr.
+func TestUnaddressableRegistrySymmetry(t *testing.T) {
+	t1 := time.Date(2017, 4, 1, 0, 0, 0, 24534, time.UTC)
+	t2 := t1.Add(time.Second)
+
+	legacy := NewCustomDeepEquals()
+	legacy.RegisterEquivalenceForType(reflect.TypeOf(t1), func(a, b unsafe.Pointer) bool {
+		aT := (*time.Time)(a)
+		bT := (*time.Time)(b)
+		return aT.Unix() == bT.Unix()
+	})
+	if !legacy.DeepEqual(wrapper{t: t1}, wrapper{t: t1}) {
+		t.Fatalf("expected equal by-value wrappers to be deep equal via the legacy registry")
+	}
+	if legacy.DeepEqual(wrapper{t: t1}, wrapper{t: t2}) {
+		t.Fatalf("expected differing by-value wrappers to not be deep equal via the legacy registry")
+	}
+
+	typed := NewCustomDeepEquals()
+	typed.AddFuncsOrDie(func(a, b time.Time) bool { return a.Unix() == b.Unix() })
+	if !typed.DeepEqual(wrapper{t: t1}, wrapper{t: t1}) {
+		t.Fatalf("expected equal by-value wrappers to be deep equal via the typed registry")
+	}
+	if typed.DeepEqual(wrapper{t: t1}, wrapper{t: t2}) {
+		t.Fatalf("expected differing by-value wrappers to not be deep equal via the typed registry")
+	}
+}
+
+func TestDeepEqualDiff(t *testing.T) {
+	customDeep := NewCustomDeepEquals()
+	two := 2
+	three := 3
+	str := "sdgv"
+	str2 := "other"
+	diffs := customDeep.DeepEqualDiff(&dtype{d: &two}, &dtype{d: &three})
+	if len(diffs) != 1 {
+		t.Fatalf("expected 1 difference, got %d: %+v", len(diffs), diffs)
+	}
+	if diffs[0].Path != "(*).d(*)" {
+		t.Fatalf("expected path (*).d(*), got %q", diffs[0].Path)
+	}
+
+	diffs = customDeep.DeepEqualDiff(&ctype{f: &str}, &ctype{f: &str2})
+	if len(diffs) != 1 || diffs[0].Reason != "string mismatch" {
+		t.Fatalf("expected a single string mismatch, got %+v", diffs)
+	}
+
+	if diffs := customDeep.DeepEqualDiff(&two, &two); len(diffs) != 0 {
+		t.Fatalf("expected no differences for equal values, got %+v", diffs)
+	}
+}
+
+type tagged struct {
+	Ignored  int     `deepequal:"ignore"`
+	Epsilon  float64 `deepequal:"epsilon=0.01"`
+	Unsorted []int   `deepequal:"set"`
+	Custom   int     `deepequal:"eq=evenOdd"`
+}
+
+func TestStructTagOptions(t *testing.T) {
+	customDeep := NewCustomDeepEquals()
+	if err := customDeep.RegisterNamedComparator("evenOdd", func(a, b int) bool {
+		return a%2 == b%2
+	}); err != nil {
+		t.Fatalf("failed to register named comparator: %v", err)
+	}
+
+	a := tagged{Ignored: 1, Epsilon: 1.0, Unsorted: []int{1, 2, 3}, Custom: 2}
+	b := tagged{Ignored: 2, Epsilon: 1.005, Unsorted: []int{3, 1, 2}, Custom: 4}
+	if !customDeep.DeepEqual(&a, &b) {
+		t.Fatalf("expected tagged structs to be equal: %+v", customDeep.DeepEqualDiff(&a, &b))
+	}
+
+	c := tagged{Ignored: 1, Epsilon: 1.0, Unsorted: []int{1, 2, 3}, Custom: 3}
+	if customDeep.DeepEqual(&a, &c) {
+		t.Fatalf("expected structs to differ on Custom field")
+	}
+}
+
+func TestRegisterUnorderedSlice(t *testing.T) {
+	customDeep := NewCustomDeepEquals()
+	customDeep.RegisterUnorderedSlice(reflect.TypeOf(0))
+
+	a := []int{1, 2, 3}
+	b := []int{3, 1, 2}
+	if !customDeep.DeepEqual(&a, &b) {
+		t.Fatalf("expected permuted slices to be equal")
+	}
+
+	c := []int{1, 2, 2}
+	if customDeep.DeepEqual(&a, &c) {
+		t.Fatalf("expected slices with different multisets to differ")
+	}
+
+	d := []int{1, 2}
+	if customDeep.DeepEqual(&a, &d) {
+		t.Fatalf("expected slices of different lengths to differ")
+	}
+}
+
+func TestPackageLevelDefault(t *testing.T) {
+	if err := RegisterEqualityFunc(func(a, b dtype) bool { return *a.d == *b.d }); err != nil {
+		t.Fatalf("failed to register on Default: %v", err)
+	}
+	two := 2
+	two2 := 2
+	if !DeepEqual(dtype{d: &two}, dtype{d: &two2}) {
+		t.Fatalf("expected dtype values to be deep equal via package-level DeepEqual")
+	}
+}
+
+func TestClone(t *testing.T) {
+	base := NewCustomDeepEquals()
+	base.AddFuncsOrDie(func(a, b time.Time) bool { return a.Unix() == b.Unix() })
+
+	clone := base.Clone()
+	clone.AddFuncsOrDie(func(a, b int) bool { return true })
+
+	if err := base.RegisterEqualityFunc(func(a, b int) bool { return a == b }); err != nil {
+		t.Fatalf("failed to register on base: %v", err)
+	}
+	if !clone.DeepEqual(1, 2) {
+		t.Fatalf("expected clone's int comparator (always equal) to be unaffected by base's later registration")
+	}
+	if base.DeepEqual(1, 2) {
+		t.Fatalf("expected base's int comparator to report 1 != 2")
+	}
+}
+
+type mtype struct {
+	m map[string]string
+}
+
+func TestDeepEqualUnexportedMapField(t *testing.T) {
+	customDeep := NewCustomDeepEquals()
+	a := &mtype{m: map[string]string{"x": "1", "y": "2"}}
+	b := &mtype{m: map[string]string{"x": "1", "y": "2"}}
+	if !customDeep.DeepEqual(a, b) {
+		t.Fatalf("expected equal unexported maps to be deep equal")
+	}
+
+	c := &mtype{m: map[string]string{"x": "1", "y": "3"}}
+	if customDeep.DeepEqual(a, c) {
+		t.Fatalf("expected differing unexported maps to not be deep equal")
+	}
+	diffs := customDeep.DeepEqualDiff(a, c)
+	if len(diffs) != 1 {
+		t.Fatalf("expected 1 difference, got %d: %+v", len(diffs), diffs)
+	}
+}
+
+func TestAddFuncsOrDie(t *testing.T) {
+	customDeep := NewCustomDeepEquals()
+	customDeep.AddFuncsOrDie(func(a, b time.Time) bool {
+		return a.Unix() == b.Unix()
+	})
+
+	t1 := time.Date(2017, 4, 1, 0, 0, 0, 24534, time.UTC)
+	t2 := time.Date(2017, 4, 1, 0, 0, 0, 33454, time.UTC)
+	if !customDeep.DeepEqual(&t1, &t2) {
+		t.Fatalf("The times were not deep equal")
+	}
+}